@@ -0,0 +1,105 @@
+// (C) 2022 TANIGUCHI Masaya
+// https://git.io/mit-license
+
+// Command reversi-relay pairs two netplay clients by room code and
+// forwards frames between them. It understands only enough of the
+// protocol to assign colors on join; everything else is blind
+// forwarding.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+var addr = flag.String("addr", ":8080", "listen address")
+
+type room struct {
+	mu    sync.Mutex
+	peers [2]*websocket.Conn
+}
+
+var (
+	roomsMu sync.Mutex
+	rooms   = map[string]*room{}
+)
+
+func roomFor(code string) *room {
+	roomsMu.Lock()
+	defer roomsMu.Unlock()
+	r, ok := rooms[code]
+	if !ok {
+		r = &room{}
+		rooms[code] = r
+	}
+	return r
+}
+
+// join seats ws in the first free slot of the room, 0 or 1.
+func (r *room) join(ws *websocket.Conn) (slot int, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, peer := range r.peers {
+		if peer == nil {
+			r.peers[i] = ws
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (r *room) leave(slot int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.peers[slot] = nil
+}
+
+func (r *room) forward(slot int, frame []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	other := r.peers[1-slot]
+	if other == nil {
+		return
+	}
+	if _, err := other.Write(frame); err != nil {
+		log.Printf("reversi-relay: forward failed: %v", err)
+	}
+}
+
+func handleRoom(ws *websocket.Conn) {
+	code := ws.Request().URL.Path
+	r := roomFor(code)
+
+	slot, ok := r.join(ws)
+	if !ok {
+		websocket.Message.Send(ws, `{"type":"resync"}`)
+		ws.Close()
+		return
+	}
+	defer r.leave(slot)
+
+	// Slot 0 is black, slot 1 is white; this is the one piece of game
+	// state the relay needs to hand out before it can start forwarding.
+	websocket.Message.Send(ws, fmt.Sprintf(`{"type":"state","turn":%d}`, slot))
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := ws.Read(buf)
+		if err != nil {
+			return
+		}
+		r.forward(slot, buf[:n])
+	}
+}
+
+func main() {
+	flag.Parse()
+	http.Handle("/room/", websocket.Handler(handleRoom))
+	log.Printf("reversi-relay: listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
@@ -0,0 +1,30 @@
+// (C) 2022 TANIGUCHI Masaya
+// https://git.io/mit-license
+
+//go:build !js
+
+package main
+
+import "flag"
+
+// config holds the command-line configuration for native builds.
+type config struct {
+	depth    int
+	start    string
+	position string
+	headless bool
+	connect  string
+	load     string
+}
+
+var cfg config
+
+func init() {
+	flag.IntVar(&cfg.depth, "depth", 6, "AI search depth")
+	flag.StringVar(&cfg.start, "start", "black", "starting player color: black or white")
+	flag.StringVar(&cfg.position, "position", "", "initial board as 64 characters (rank 1 to rank 8, a to h): 'b' black, 'w' white, '-' empty")
+	flag.BoolVar(&cfg.headless, "headless", false, "run without a window, driving the game on stdout")
+	flag.StringVar(&cfg.connect, "connect", "", "netplay room URL to connect to, e.g. wss://host/room/XYZ")
+	flag.StringVar(&cfg.load, "load", "", "transcript (.rgf) file to replay for review instead of starting a new game")
+	flag.Parse()
+}
@@ -0,0 +1,19 @@
+// (C) 2022 TANIGUCHI Masaya
+// https://git.io/mit-license
+
+//go:build js
+
+package main
+
+// config mirrors flags.go's fields, but WASM builds take no flags and
+// always run the Ebiten UI.
+type config struct {
+	depth    int
+	start    string
+	position string
+	headless bool
+	connect  string
+	load     string
+}
+
+var cfg = config{depth: 6, start: "black"}
@@ -0,0 +1,98 @@
+// (C) 2022 TANIGUCHI Masaya
+// https://git.io/mit-license
+
+//go:build !js
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/bits"
+	"os"
+	"strings"
+
+	"github.com/tani/go-reversi/reversi"
+	"github.com/tani/go-reversi/reversi/ai"
+)
+
+// runHeadless drives a game to completion without opening a window.
+// Each move is read from stdin in coordinate notation (e.g. "e4"); a
+// line that is not a legal move is replaced by the AI's move, and once
+// stdin ends, the AI plays the rest of the game for both sides. The
+// board and the full move list are printed to stdout.
+func runHeadless(board reversi.Board) {
+	scanner := bufio.NewScanner(os.Stdin)
+	searcher := ai.NewSearcher(cfg.depth, searchTimeBudget)
+	searcher.Book = ai.LoadBook()
+	var moves []string
+
+	printBoard(board)
+	for board.LegalMoves() != 0 {
+		candidates := board.LegalMoves()
+
+		move, ok := uint64(0), false
+		if scanner.Scan() {
+			move, ok = parseCoordinate(scanner.Text())
+			ok = ok && move&candidates != 0
+		}
+		if !ok {
+			move, _ = searcher.Search(context.Background(), board)
+		}
+
+		moves = append(moves, formatCoordinate(move))
+		board = board.Apply(move)
+		printBoard(board)
+	}
+
+	fmt.Println("moves:", strings.Join(moves, " "))
+	if winner, ok := board.Winner(); ok {
+		fmt.Println("winner:", winner)
+	} else {
+		fmt.Println("draw")
+	}
+}
+
+// printBoard writes an 8x8 grid of the board to stdout, 'B' for black,
+// 'W' for white, '.' for empty.
+func printBoard(board reversi.Board) {
+	for y := 0; y < 8; y++ {
+		row := make([]byte, 8)
+		for x := 0; x < 8; x++ {
+			position := uint64(1) << (x + y*8)
+			switch {
+			case position&board.Black > 0:
+				row[x] = 'B'
+			case position&board.White > 0:
+				row[x] = 'W'
+			default:
+				row[x] = '.'
+			}
+		}
+		fmt.Println(string(row))
+	}
+	fmt.Printf("BLACK: %d WHITE: %d\n", bits.OnesCount64(board.Black), bits.OnesCount64(board.White))
+}
+
+// parseCoordinate parses a move like "e4" (column a-h, row 1-8) into a
+// single-bit position bitmask.
+func parseCoordinate(s string) (position uint64, ok bool) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if len(s) != 2 {
+		return 0, false
+	}
+	if s[0] < 'a' || s[0] > 'h' || s[1] < '1' || s[1] > '8' {
+		return 0, false
+	}
+	x := int(s[0] - 'a')
+	y := int(s[1] - '1')
+	return uint64(1) << (x + y*8), true
+}
+
+// formatCoordinate is the inverse of parseCoordinate.
+func formatCoordinate(position uint64) string {
+	index := bits.TrailingZeros64(position)
+	x, y := index%8, index/8
+	return fmt.Sprintf("%c%d", 'a'+x, y+1)
+}
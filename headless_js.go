@@ -0,0 +1,12 @@
+// (C) 2022 TANIGUCHI Masaya
+// https://git.io/mit-license
+
+//go:build js
+
+package main
+
+import "github.com/tani/go-reversi/reversi"
+
+// runHeadless is unused in the browser since cfg.headless is always
+// false, but is kept so main.go compiles unchanged.
+func runHeadless(board reversi.Board) {}
@@ -5,19 +5,25 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"embed"
 	"fmt"
 	"image/color"
 	_ "image/png"
-	"math"
 	"math/bits"
 	"sync/atomic"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/text"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/opentype"
+
+	"github.com/tani/go-reversi/netplay"
+	"github.com/tani/go-reversi/reversi"
+	"github.com/tani/go-reversi/reversi/ai"
 )
 
 //go:embed assets/*
@@ -40,143 +46,71 @@ func init() {
 	whiteImg, _, _ = ebitenutil.NewImageFromReader(bytes.NewReader(whiteBin))
 }
 
-const VerticalMask uint64 = 0x00ffffffffffff00
-const HorizontalMask uint64 = 0x7e7e7e7e7e7e7e7e
-const EdgeMask uint64 = ^VerticalMask | ^HorizontalMask
-const CornerMask uint64 = ^VerticalMask & ^(VerticalMask ^ HorizontalMask)
-
-func GetCandidates(black, white uint64) uint64 {
-	mask := [4]uint64{
-		white & HorizontalMask,
-		white & HorizontalMask & VerticalMask,
-		white & VerticalMask,
-		white & HorizontalMask & VerticalMask,
-	}
-	diff := [4]uint64{1, 7, 8, 9}
-	result1 := uint64(0)
-	result2 := uint64(0)
-	for i := 0; i < 4; i++ {
-		pattern1 := mask[i] & (black << diff[i])
-		pattern2 := mask[i] & (black >> diff[i])
-		for j := 0; j < 5; j++ {
-			pattern1 |= mask[i] & (pattern1 << diff[i])
-			pattern2 |= mask[i] & (pattern2 >> diff[i])
-		}
-		result1 |= (pattern1 << diff[i])
-		result2 |= (pattern2 >> diff[i])
-	}
-	return (result1 | result2) & ^(black | white)
-}
-
-func GetReverse(black, white, position uint64) uint64 {
-	mask := [4]uint64{
-		white & HorizontalMask,
-		white & HorizontalMask & VerticalMask,
-		white & VerticalMask,
-		white & HorizontalMask & VerticalMask,
-	}
-	diff := [4]uint64{1, 7, 8, 9}
-	result := uint64(0)
-	for i := 0; i < 4; i++ {
-		pattern1 := mask[i] & (black << diff[i])
-		pattern2 := mask[i] & (position >> diff[i])
-		pattern3 := mask[i] & (black >> diff[i])
-		pattern4 := mask[i] & (position << diff[i])
-		for j := 0; j < 5; j++ {
-			pattern1 |= mask[i] & (pattern1 << diff[i])
-			pattern2 |= mask[i] & (pattern2 >> diff[i])
-			pattern3 |= mask[i] & (pattern3 >> diff[i])
-			pattern4 |= mask[i] & (pattern4 << diff[i])
-		}
-		result |= (pattern1 & pattern2) | (pattern3 & pattern4)
-	}
-	return result
-}
+type Game struct {
+	cellSize    int
+	boardSize   int
+	boardMargin int
+	board       reversi.Board
+	lock        int64
 
-func EvaluatePartial(black, white uint64) int {
-	positionScore := bits.OnesCount64(black&EdgeMask) - bits.OnesCount64(white&EdgeMask)
-	blackCandidates := GetCandidates(black, white)
-	whiteCandidates := GetCandidates(white, black)
-	mobilityScore := bits.OnesCount64(blackCandidates) - bits.OnesCount64(whiteCandidates)
-	return mobilityScore + 4*positionScore
-}
+	// localColor is the color the person at this keyboard plays. It is
+	// always Black offline, where White is the AI's color, but may be
+	// either color in a netplay match.
+	localColor reversi.Player
+	// net is non-nil when this Game is a netplay match; the opponent's
+	// moves come from it instead of from searcher.
+	net *netplay.Session
 
-func EvaluateComplete(black, white uint64) int {
-	return bits.OnesCount64(black) - bits.OnesCount64(white)
-}
+	searcher *ai.Searcher
 
-func Evaluate(black, white uint64, depth int, player int, minimumScore, maximumScore int) int {
-	if depth == 0 {
-		return EvaluatePartial(black, white)
-	}
-	if player == COM {
-		candidates := GetCandidates(white, black)
-		nbits := bits.OnesCount64(candidates)
-		minimalScore := math.MaxInt
-		for i := 0; i < nbits; i++ {
-			position := uint64(1) << (63 - bits.LeadingZeros64(candidates))
-			reverse := GetReverse(white, black, position)
-			white := white ^ reverse ^ position
-			black := black ^ reverse
-			score := Evaluate(black, white, depth-1, YOU, minimumScore, maximumScore)
-			if score < minimalScore {
-				minimalScore = score
-			}
-			if minimalScore <= minimumScore {
-				break
-			}
-			if minimalScore < maximumScore {
-				maximumScore = minimalScore
-			}
-		}
-		return minimumScore
-	} else { // YOU
-		candidates := GetCandidates(black, white)
-		nbits := bits.OnesCount64(candidates)
-		maximalScore := math.MinInt
-		for i := 0; i < nbits; i++ {
-			position := uint64(1) << (63 - bits.LeadingZeros64(candidates))
-			reverse := GetReverse(black, white, position)
-			white := white ^ reverse
-			black := black ^ reverse ^ position
-			score := Evaluate(black, white, depth-1, COM, minimumScore, maximumScore)
-			if score > maximalScore {
-				maximalScore = score
-			}
-			if maximalScore >= maximumScore {
-				break
-			}
-			if maximalScore > minimumScore {
-				minimumScore = maximalScore
-			}
-		}
-		return maximumScore
-	}
-}
+	// blackWins, whiteWins, and draws tally completed games and survive
+	// a RESET, which only clears the board.
+	blackWins, whiteWins, draws int
+	moves                       []moveRecord
 
-const (
-	YOU = iota
-	COM
-)
+	// review, when non-nil, puts the Game in read-only replay mode over
+	// a loaded transcript: reviewIndex selects which board to show, and
+	// Left/Right step through review.
+	review      []reversi.Board
+	reviewIndex int
 
-const initialBlack = (uint64(1) << (8*3 + 4)) | (uint64(1) << (8*4 + 3))
-const initialWhite = (uint64(1) << (8*4 + 4)) | (uint64(1) << (8*3 + 3))
+	// lastMove is the most recently played square, or 0 before the
+	// first move of a game; Draw outlines it for the player.
+	lastMove uint64
+}
 
-type Game struct {
-	cellSize     int
-	boardSize    int
-	boardMargin  int
-	player       int
-	black, white uint64
-	lock         int64
+// moveRecord is one ply of a game, kept to write a transcript once the
+// game ends.
+type moveRecord struct {
+	player   reversi.Player
+	position uint64
 }
 
+// searchTimeBudget bounds how long the AI may spend per move.
+const searchTimeBudget = 5 * time.Second
+
 func (game *Game) Update() error {
 	if atomic.CompareAndSwapInt64(&game.lock, 0, 1) {
 		return nil
 	}
 	defer atomic.StoreInt64(&game.lock, 0)
 
+	if game.review != nil {
+		if inpututil.IsKeyJustPressed(ebiten.KeyArrowRight) && game.reviewIndex < len(game.review)-1 {
+			game.reviewIndex++
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft) && game.reviewIndex > 0 {
+			game.reviewIndex--
+		}
+		game.board = game.review[game.reviewIndex]
+		return nil
+	}
+
+	if game.board.GameOver() {
+		game.finishGame()
+		return nil
+	}
+
 	cursorX, cursorY := ebiten.CursorPosition()
 
 	ids := ebiten.AppendTouchIDs([]ebiten.TouchID{})
@@ -184,21 +118,21 @@ func (game *Game) Update() error {
 		cursorX, cursorY = ebiten.TouchPosition(ids[0])
 	}
 
-	if game.boardMargin+340 < cursorX && cursorX < game.boardMargin+400 {
+	if game.net == nil && game.boardMargin+340 < cursorX && cursorX < game.boardMargin+400 {
 		if 15 < cursorY && cursorY < 35 {
 			if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) || len(ids) != 0 {
-				game.black = initialBlack
-				game.white = initialWhite
-				game.player = YOU
+				game.board = reversi.NewBoard()
+				game.moves = nil
+				game.lastMove = 0
 				return nil
 			}
 		}
 	}
 
-	if game.player == YOU {
-		candidates := GetCandidates(game.black, game.white)
+	if game.board.Turn == game.localColor {
+		candidates := game.board.LegalMoves()
 		if candidates == 0 {
-			game.player = COM
+			game.board.Turn = game.board.Turn.Opponent()
 			return nil
 		}
 
@@ -213,57 +147,110 @@ func (game *Game) Update() error {
 			positionY := (cursorY - game.boardMargin) / game.cellSize
 			position := uint64(1) << (positionX + positionY*8)
 			if (position & candidates) > 0 {
-				reverse := GetReverse(game.black, game.white, position)
-				game.black ^= reverse ^ position
-				game.white ^= reverse
-				game.player = COM
+				game.play(position)
+				if game.net != nil {
+					game.net.SendMove(position)
+				}
 			}
 		}
+	} else if game.net != nil {
+		game.updateRemote()
 	} else {
-		candidates := GetCandidates(game.white, game.black)
-		bestBlack := game.black
-		bestWhite := game.white
-		bestScore := math.MinInt
-		for candidates > 0 {
-			position := uint64(1) << (63 - bits.LeadingZeros64(candidates))
-			reverse := GetReverse(game.white, game.black, position)
-			white := game.white ^ reverse ^ position
-			black := game.black ^ reverse
-			score := Evaluate(white, black, 6, YOU, math.MinInt, math.MaxInt)
-			if bestScore < score {
-				bestBlack = black
-				bestWhite = white
-				bestScore = score
-			}
-			candidates -= position
+		candidates := game.board.LegalMoves()
+		if candidates == 0 {
+			game.board.Turn = game.board.Turn.Opponent()
+			return nil
 		}
-		game.black = bestBlack
-		game.white = bestWhite
-		game.player = YOU
+
+		move, _ := game.searcher.Search(context.Background(), game.board)
+		game.play(move)
 	}
 	return nil
 }
 
+// play applies position for the player to move and records it for the
+// match transcript.
+func (game *Game) play(position uint64) {
+	game.moves = append(game.moves, moveRecord{player: game.board.Turn, position: position})
+	game.board = game.board.Apply(position)
+	game.lastMove = position
+}
+
+// finishGame tallies the completed match, writes its transcript, and
+// resets the board for the next game without touching the tally.
+func (game *Game) finishGame() {
+	if winner, ok := game.board.Winner(); !ok {
+		game.draws++
+	} else if winner == reversi.Black {
+		game.blackWins++
+	} else {
+		game.whiteWins++
+	}
+	writeTranscript(game.moves, game.board)
+	game.board = reversi.NewBoard()
+	game.moves = nil
+	game.lastMove = 0
+}
+
+// updateRemote applies the opponent's moves as they arrive over the
+// netplay session, rejecting anything that isn't currently legal. If
+// the connection has dropped, it tries to reconnect before anything
+// else; a failed attempt is silently retried on the next frame.
+func (game *Game) updateRemote() {
+	if game.net.Disconnected() {
+		if err := game.net.Reconnect(); err != nil {
+			return
+		}
+	}
+
+	msg, ok := game.net.Poll()
+	if !ok {
+		return
+	}
+	switch msg.Type {
+	case netplay.TypeMove:
+		if msg.Pos&game.board.LegalMoves() == 0 {
+			game.net.RequestResync()
+			return
+		}
+		game.play(msg.Pos)
+	case netplay.TypeState:
+		game.board = reversi.Board{Black: msg.Black, White: msg.White, Turn: reversi.Player(msg.Turn)}
+	case netplay.TypeResync:
+		game.net.SendState(game.board)
+	case netplay.TypeResign:
+		game.board.Turn = game.localColor
+	}
+}
+
 func (game *Game) Draw(screen *ebiten.Image) {
 	ebitenutil.DrawRect(screen, 0, 0, float64(game.boardSize+game.boardMargin*2), float64(game.boardSize+game.boardMargin*2), color.RGBA{0x00, 0xff, 0x00, 0xff})
 	for i := 0; i <= 8; i++ {
 		ebitenutil.DrawLine(screen, float64(game.cellSize*i+game.boardMargin), float64(game.boardMargin), float64(game.cellSize*i+game.boardMargin), float64(game.boardSize+game.boardMargin), color.Black)
 		ebitenutil.DrawLine(screen, float64(game.boardMargin), float64(game.cellSize*i+game.boardMargin), float64(game.boardSize+game.boardMargin), float64(game.cellSize*i+game.boardMargin), color.Black)
 	}
-	msg := fmt.Sprintf("BLACK: %d WHITE: %d", bits.OnesCount64(game.black), bits.OnesCount64(game.white))
+	msg := fmt.Sprintf("BLACK: %d WHITE: %d", bits.OnesCount64(game.board.Black), bits.OnesCount64(game.board.White))
 	text.Draw(screen, msg, fontFace, game.boardMargin, 30, color.Black)
+	tally := fmt.Sprintf("B-WINS:%d W-WINS:%d DRAWS:%d", game.blackWins, game.whiteWins, game.draws)
+	text.Draw(screen, tally, fontFace, game.boardMargin, 45, color.Black)
 	ebitenutil.DrawRect(screen, float64(game.boardMargin+340), 15, 60, 20, color.Black)
 	text.Draw(screen, "RESET", fontFace, game.boardMargin+345, 30, color.RGBA{0x00, 0xff, 0x00, 0xff})
+
+	if game.review == nil && game.board.Turn == game.localColor {
+		game.drawLegalMoveHints(screen)
+	}
+	game.drawLastMove(screen)
+
 	for i := 0; i < 8; i++ {
 		for j := 0; j < 8; j++ {
 			position := uint64(1) << (i + j*8)
 			option := &ebiten.DrawImageOptions{}
 			option.GeoM.Translate(float64(i*game.cellSize+game.boardMargin), float64(j*game.cellSize+game.boardMargin))
-			if position&game.black > 0 {
+			if position&game.board.Black > 0 {
 				screen.DrawImage(blackImg, option)
 				continue
 			}
-			if position&game.white > 0 {
+			if position&game.board.White > 0 {
 				screen.DrawImage(whiteImg, option)
 				continue
 			}
@@ -271,19 +258,136 @@ func (game *Game) Draw(screen *ebiten.Image) {
 	}
 }
 
+// drawLegalMoveHints marks every square the player to move may play
+// with a small translucent dot, and previews the flip count under the
+// cursor when it hovers one of them.
+func (game *Game) drawLegalMoveHints(screen *ebiten.Image) {
+	candidates := game.board.LegalMoves()
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			position := uint64(1) << (i + j*8)
+			if position&candidates == 0 {
+				continue
+			}
+			const dotSize = 8
+			cx := float64(i*game.cellSize+game.boardMargin) + float64(game.cellSize-dotSize)/2
+			cy := float64(j*game.cellSize+game.boardMargin) + float64(game.cellSize-dotSize)/2
+			ebitenutil.DrawRect(screen, cx, cy, dotSize, dotSize, color.RGBA{0x00, 0x00, 0x00, 0x60})
+		}
+	}
+
+	cursorX, cursorY := ebiten.CursorPosition()
+	if position, ok := game.hoveredSquare(cursorX, cursorY); ok && position&candidates != 0 {
+		flips := game.board.FlipCount(position)
+		text.Draw(screen, fmt.Sprintf("%d", flips), fontFace, cursorX+10, cursorY, color.Black)
+	}
+}
+
+// drawLastMove outlines the most recently played square in red.
+func (game *Game) drawLastMove(screen *ebiten.Image) {
+	if game.lastMove == 0 {
+		return
+	}
+	index := bits.TrailingZeros64(game.lastMove)
+	x, y := index%8, index/8
+	left := float64(x*game.cellSize + game.boardMargin)
+	top := float64(y*game.cellSize + game.boardMargin)
+	size := float64(game.cellSize)
+	const thickness = 3
+	red := color.RGBA{0xff, 0x00, 0x00, 0xff}
+	ebitenutil.DrawRect(screen, left, top, size, thickness, red)
+	ebitenutil.DrawRect(screen, left, top+size-thickness, size, thickness, red)
+	ebitenutil.DrawRect(screen, left, top, thickness, size, red)
+	ebitenutil.DrawRect(screen, left+size-thickness, top, thickness, size, red)
+}
+
+// hoveredSquare translates a cursor position into the board square
+// underneath it, if any.
+func (game *Game) hoveredSquare(cursorX, cursorY int) (position uint64, ok bool) {
+	if !(game.boardMargin < cursorX && cursorX < game.boardSize+game.boardMargin) {
+		return 0, false
+	}
+	if !(game.boardMargin < cursorY && cursorY < game.boardSize+game.boardMargin) {
+		return 0, false
+	}
+	positionX := (cursorX - game.boardMargin) / game.cellSize
+	positionY := (cursorY - game.boardMargin) / game.cellSize
+	return uint64(1) << (positionX + positionY*8), true
+}
+
 func (game *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
 	return game.boardSize + game.boardMargin*2, game.boardSize + game.boardMargin*2
 }
 
+// newBoardFromConfig builds the starting board from the -position and
+// -start flags, falling back to the standard Othello opening.
+func newBoardFromConfig() reversi.Board {
+	board := reversi.NewBoard()
+	if cfg.position != "" {
+		board = parsePosition(cfg.position)
+	}
+	if cfg.start == "white" {
+		board.Turn = reversi.White
+	}
+	return board
+}
+
+// parsePosition reads a 64-character board layout (rank 1 to rank 8,
+// column a to h): 'b'/'B' for black, 'w'/'W' for white, anything else
+// empty.
+func parsePosition(s string) reversi.Board {
+	board := reversi.Board{Turn: reversi.Black}
+	for i := 0; i < len(s) && i < 64; i++ {
+		switch s[i] {
+		case 'b', 'B':
+			board.Black |= uint64(1) << i
+		case 'w', 'W':
+			board.White |= uint64(1) << i
+		}
+	}
+	return board
+}
+
 func main() {
+	board := newBoardFromConfig()
+	if cfg.headless {
+		runHeadless(board)
+		return
+	}
+
+	searcher := ai.NewSearcher(cfg.depth, searchTimeBudget)
+	searcher.Book = ai.LoadBook()
+
 	game := &Game{
 		cellSize:    50,
 		boardMargin: 50,
 		boardSize:   50 * 8,
-		player:      YOU,
-		black:       initialBlack,
-		white:       initialWhite,
+		board:       board,
+		localColor:  reversi.Black,
+		searcher:    searcher,
+	}
+
+	if cfg.load != "" {
+		review, err := loadReview(cfg.load)
+		if err != nil {
+			panic(err)
+		}
+		game.review = review
+		game.board = review[0]
 	}
+
+	if cfg.connect != "" {
+		session, err := netplay.Dial(cfg.connect)
+		if err != nil {
+			panic(err)
+		}
+		if err := session.WaitReady(); err != nil {
+			panic(err)
+		}
+		game.net = session
+		game.localColor = session.Color
+	}
+
 	ebiten.SetWindowSize(640, 480)
 	ebiten.SetWindowTitle("Hello world")
 	if err := ebiten.RunGame(game); err != nil {
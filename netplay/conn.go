@@ -0,0 +1,78 @@
+// (C) 2022 TANIGUCHI Masaya
+// https://git.io/mit-license
+
+//go:build !js
+
+package netplay
+
+import "golang.org/x/net/websocket"
+
+// wsConn is the native Conn, backed by golang.org/x/net/websocket. A
+// background goroutine drains incoming frames into a buffered channel
+// so Poll never blocks.
+type wsConn struct {
+	ws     *websocket.Conn
+	msgs   chan Message
+	err    chan error
+	closed chan struct{}
+}
+
+func dial(url string) (Conn, error) {
+	ws, err := websocket.Dial(url, "", "http://localhost/")
+	if err != nil {
+		return nil, err
+	}
+	c := &wsConn{ws: ws, msgs: make(chan Message, 16), err: make(chan error, 1), closed: make(chan struct{})}
+	go c.readLoop()
+	return c, nil
+}
+
+func (c *wsConn) readLoop() {
+	for {
+		var msg Message
+		if err := websocket.JSON.Receive(c.ws, &msg); err != nil {
+			c.err <- err
+			close(c.msgs)
+			close(c.closed)
+			return
+		}
+		c.msgs <- msg
+	}
+}
+
+func (c *wsConn) Send(msg Message) error {
+	return websocket.JSON.Send(c.ws, msg)
+}
+
+func (c *wsConn) Receive() (Message, error) {
+	msg, ok := <-c.msgs
+	if !ok {
+		return Message{}, <-c.err
+	}
+	return msg, nil
+}
+
+func (c *wsConn) Poll() (Message, bool) {
+	select {
+	case msg, ok := <-c.msgs:
+		if !ok {
+			return Message{}, false
+		}
+		return msg, true
+	default:
+		return Message{}, false
+	}
+}
+
+func (c *wsConn) Closed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *wsConn) Close() error {
+	return c.ws.Close()
+}
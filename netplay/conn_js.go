@@ -0,0 +1,82 @@
+// (C) 2022 TANIGUCHI Masaya
+// https://git.io/mit-license
+
+//go:build js
+
+package netplay
+
+import (
+	"encoding/json"
+	"errors"
+	"syscall/js"
+)
+
+// jsConn is the WASM Conn, backed by the browser's WebSocket, since
+// syscall/js programs cannot use golang.org/x/net/websocket directly.
+type jsConn struct {
+	socket js.Value
+	msgs   chan Message
+	closed chan struct{}
+}
+
+func dial(url string) (Conn, error) {
+	socket := js.Global().Get("WebSocket").New(url)
+	c := &jsConn{socket: socket, msgs: make(chan Message, 16), closed: make(chan struct{})}
+
+	socket.Set("onmessage", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		var msg Message
+		if err := json.Unmarshal([]byte(args[0].Get("data").String()), &msg); err == nil {
+			c.msgs <- msg
+		}
+		return nil
+	}))
+	socket.Set("onclose", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		close(c.closed)
+		return nil
+	}))
+
+	return c, nil
+}
+
+func (c *jsConn) Send(msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.socket.Call("send", string(data))
+	return nil
+}
+
+func (c *jsConn) Receive() (Message, error) {
+	select {
+	case msg := <-c.msgs:
+		return msg, nil
+	case <-c.closed:
+		return Message{}, errClosed
+	}
+}
+
+func (c *jsConn) Poll() (Message, bool) {
+	select {
+	case msg := <-c.msgs:
+		return msg, true
+	default:
+		return Message{}, false
+	}
+}
+
+func (c *jsConn) Closed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *jsConn) Close() error {
+	c.socket.Call("close")
+	return nil
+}
+
+var errClosed = errors.New("netplay: connection closed")
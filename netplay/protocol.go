@@ -0,0 +1,55 @@
+// (C) 2022 TANIGUCHI Masaya
+// https://git.io/mit-license
+
+// Package netplay implements two-player online matches carried over a
+// websocket relay. It knows nothing about rendering; callers feed it
+// local moves and poll it for remote ones.
+package netplay
+
+// MessageType identifies the kind of frame exchanged between peers.
+type MessageType string
+
+const (
+	// TypeReady is sent once a client has joined its room, and again
+	// is awaited from the peer before play may start; a client answers
+	// the peer's ready with one more.
+	TypeReady MessageType = "ready"
+	// TypeMove carries a single move, Pos is the bitmask of the played square.
+	TypeMove MessageType = "move"
+	// TypeState carries a full board, used both by the relay to assign
+	// colors on join (Turn holds the assigned slot) and by peers to
+	// resync each other's board.
+	TypeState MessageType = "state"
+	// TypeResync asks the peer to send its current board as TypeState.
+	TypeResync MessageType = "resync"
+	// TypeResign tells the peer the sender has given up the match.
+	TypeResign MessageType = "resign"
+)
+
+// Message is the JSON frame exchanged over the websocket connection.
+// Only the fields relevant to Type are populated.
+type Message struct {
+	Type  MessageType `json:"type"`
+	Pos   uint64      `json:"pos,omitempty"`
+	Black uint64      `json:"black,omitempty"`
+	White uint64      `json:"white,omitempty"`
+	Turn  int         `json:"turn,omitempty"`
+}
+
+// Conn is a transport for Messages. It is implemented separately for
+// native builds (conn.go, over golang.org/x/net/websocket) and WASM
+// builds (conn_js.go, over the browser's WebSocket).
+type Conn interface {
+	Send(Message) error
+	// Receive blocks until a message arrives or the connection closes.
+	Receive() (Message, error)
+	// Poll returns the next buffered message without blocking; ok is
+	// false if none is available yet. It cannot distinguish "nothing
+	// buffered" from "connection closed" — callers that care use Closed.
+	Poll() (Message, bool)
+	// Closed reports whether the connection has failed or been closed,
+	// so a caller polling in a loop can tell a dropped peer apart from
+	// an idle one and react (e.g. by reconnecting).
+	Closed() bool
+	Close() error
+}
@@ -0,0 +1,105 @@
+// (C) 2022 TANIGUCHI Masaya
+// https://git.io/mit-license
+
+package netplay
+
+import "github.com/tani/go-reversi/reversi"
+
+// Session is a live match with a remote peer: the local player's color
+// plus the underlying Conn. dial is platform-specific (conn.go,
+// conn_js.go).
+type Session struct {
+	conn  Conn
+	url   string
+	Color reversi.Player
+}
+
+// Dial connects to a room URL such as "wss://host/room/XYZ". The relay
+// replies with the color it assigned this connection (black for the
+// first peer to join the room, white for the second) before any game
+// traffic flows.
+func Dial(url string) (*Session, error) {
+	conn, err := dial(url)
+	if err != nil {
+		return nil, err
+	}
+	assignment, err := conn.Receive()
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Send(Message{Type: TypeReady}); err != nil {
+		return nil, err
+	}
+	return &Session{conn: conn, url: url, Color: reversi.Player(assignment.Turn)}, nil
+}
+
+// WaitReady blocks until the peer's ready handshake frame arrives,
+// confirming both sides have joined the room and play may start. It
+// answers that frame with a ready of its own, since the relay drops the
+// ready a client sends while it is still alone in the room.
+func (s *Session) WaitReady() error {
+	for {
+		msg, err := s.conn.Receive()
+		if err != nil {
+			return err
+		}
+		if msg.Type == TypeReady {
+			return s.conn.Send(Message{Type: TypeReady})
+		}
+	}
+}
+
+// SendMove sends a move played by the local player.
+func (s *Session) SendMove(pos uint64) error {
+	return s.conn.Send(Message{Type: TypeMove, Pos: pos})
+}
+
+// SendState sends the full board, used to resync the peer.
+func (s *Session) SendState(board reversi.Board) error {
+	return s.conn.Send(Message{Type: TypeState, Black: board.Black, White: board.White, Turn: int(board.Turn)})
+}
+
+// RequestResync asks the peer to send its board back as TypeState.
+func (s *Session) RequestResync() error {
+	return s.conn.Send(Message{Type: TypeResync})
+}
+
+// SendResign tells the peer the local player has resigned.
+func (s *Session) SendResign() error {
+	return s.conn.Send(Message{Type: TypeResign})
+}
+
+// Poll returns the next message from the peer without blocking.
+func (s *Session) Poll() (Message, bool) {
+	return s.conn.Poll()
+}
+
+// Disconnected reports whether the underlying connection has dropped.
+// Callers polling in a loop should check this and call Reconnect,
+// since Poll alone can't tell a dropped peer from an idle one.
+func (s *Session) Disconnected() bool {
+	return s.conn.Closed()
+}
+
+// Reconnect redials the room URL, replacing the underlying connection,
+// and then calls RequestResync to recover from whatever was missed
+// while disconnected.
+func (s *Session) Reconnect() error {
+	conn, err := dial(s.url)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Receive(); err != nil {
+		return err
+	}
+	if err := conn.Send(Message{Type: TypeReady}); err != nil {
+		return err
+	}
+	s.conn = conn
+	return s.RequestResync()
+}
+
+// Close releases the underlying connection.
+func (s *Session) Close() error {
+	return s.conn.Close()
+}
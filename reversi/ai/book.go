@@ -0,0 +1,75 @@
+// (C) 2022 TANIGUCHI Masaya
+// https://git.io/mit-license
+
+package ai
+
+import (
+	"embed"
+	"math/rand"
+	"strings"
+
+	"github.com/tani/go-reversi/reversi"
+)
+
+//go:embed book.txt
+var bookFS embed.FS
+
+// Book maps the Zobrist hash of a position to the candidate replies
+// drawn from known lines that reach it. Named lines commonly share an
+// early prefix (the diagonal, perpendicular, and parallel openings all
+// begin with the same first move), so a single position often holds
+// more than one recorded reply.
+type Book map[uint64][]uint64
+
+// Lookup returns a reply for hash, chosen at random among any recorded
+// candidates so play drawn from several book lines doesn't always
+// collapse onto the same one. ok is false if hash isn't in the book.
+func (b Book) Lookup(hash uint64) (move uint64, ok bool) {
+	moves, found := b[hash]
+	if !found {
+		return 0, false
+	}
+	return moves[rand.Intn(len(moves))], true
+}
+
+// LoadBook parses the embedded opening book. Each non-comment line is a
+// sequence of moves in coordinate notation (e.g. "e6 f4 e3 ..."),
+// replayed from the standard opening to derive the Zobrist hash at each
+// ply; a line that goes off known theory (an unparseable or illegal
+// move) simply stops contributing further entries from that point on.
+func LoadBook() Book {
+	book := Book{}
+	data, err := bookFS.ReadFile("book.txt")
+	if err != nil {
+		return book
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		board := reversi.NewBoard()
+		for _, coordinate := range strings.Fields(line) {
+			position, ok := parseCoordinate(coordinate)
+			if !ok || position&board.LegalMoves() == 0 {
+				break
+			}
+			hash := zobrist(board)
+			book[hash] = append(book[hash], position)
+			board = board.Apply(position)
+		}
+	}
+	return book
+}
+
+// parseCoordinate parses a move like "e4" (column a-h, row 1-8) into a
+// single-bit position bitmask.
+func parseCoordinate(s string) (position uint64, ok bool) {
+	s = strings.ToLower(s)
+	if len(s) != 2 || s[0] < 'a' || s[0] > 'h' || s[1] < '1' || s[1] > '8' {
+		return 0, false
+	}
+	x := int(s[0] - 'a')
+	y := int(s[1] - '1')
+	return uint64(1) << (x + y*8), true
+}
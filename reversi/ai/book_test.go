@@ -0,0 +1,80 @@
+// (C) 2022 TANIGUCHI Masaya
+// https://git.io/mit-license
+
+package ai
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tani/go-reversi/reversi"
+)
+
+// TestBookLinesParseToCompletion guards against the book regressing
+// into decorative data: every move in every line must be legal against
+// the engine, or LoadBook silently stops contributing entries partway
+// through that line.
+func TestBookLinesParseToCompletion(t *testing.T) {
+	data, err := bookFS.ReadFile("book.txt")
+	if err != nil {
+		t.Fatalf("reading book.txt: %v", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		board := reversi.NewBoard()
+		for i, coordinate := range strings.Fields(line) {
+			position, ok := parseCoordinate(coordinate)
+			if !ok {
+				t.Fatalf("line %q: %q is not a valid coordinate", line, coordinate)
+			}
+			if position&board.LegalMoves() == 0 {
+				t.Fatalf("line %q: move %d (%s) is not legal", line, i+1, coordinate)
+			}
+			board = board.Apply(position)
+		}
+	}
+}
+
+// TestBookLinesAreAllReachable guards against named lines silently
+// losing moves where they share a prefix: LoadBook must record every
+// line's reply at every ply, not just the last line loaded for a given
+// position.
+func TestBookLinesAreAllReachable(t *testing.T) {
+	data, err := bookFS.ReadFile("book.txt")
+	if err != nil {
+		t.Fatalf("reading book.txt: %v", err)
+	}
+	book := LoadBook()
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		board := reversi.NewBoard()
+		for i, coordinate := range strings.Fields(line) {
+			position, ok := parseCoordinate(coordinate)
+			if !ok {
+				t.Fatalf("line %q: %q is not a valid coordinate", line, coordinate)
+			}
+
+			recorded := false
+			for _, candidate := range book[zobrist(board)] {
+				if candidate == position {
+					recorded = true
+					break
+				}
+			}
+			if !recorded {
+				t.Fatalf("line %q: move %d (%s) was not recorded in the loaded book for this position", line, i+1, coordinate)
+			}
+			board = board.Apply(position)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// (C) 2022 TANIGUCHI Masaya
+// https://git.io/mit-license
+
+// Package ai implements game-tree search over reversi.Board.
+package ai
+
+import (
+	"context"
+	"math"
+	"math/bits"
+	"sort"
+	"time"
+
+	"github.com/tani/go-reversi/reversi"
+)
+
+// Evaluator scores a board from Black's perspective: positive favors
+// Black, negative favors White.
+type Evaluator func(board reversi.Board) int
+
+// Partial is the default evaluator away from the end game.
+func Partial(board reversi.Board) int {
+	return reversi.EvaluatePartial(board.Black, board.White)
+}
+
+// Complete is an exact evaluator for the end game, by stone count.
+func Complete(board reversi.Board) int {
+	return reversi.EvaluateComplete(board.Black, board.White)
+}
+
+// endgameEmpties is the number of empty squares at or below which
+// Searcher switches from the partial evaluator to solving the rest of
+// the game exactly with Complete.
+const endgameEmpties = 14
+
+// Searcher holds the tuning knobs for one difficulty level, plus the
+// transposition table and opening book it consults across moves. The
+// zero value is not usable; build one with NewSearcher.
+type Searcher struct {
+	MaxDepth   int
+	TimeBudget time.Duration
+	Book       Book
+
+	tt map[uint64]ttEntry
+}
+
+// NewSearcher returns a Searcher ready to play at the given depth and
+// per-move time budget.
+func NewSearcher(maxDepth int, timeBudget time.Duration) *Searcher {
+	return &Searcher{MaxDepth: maxDepth, TimeBudget: timeBudget, tt: make(map[uint64]ttEntry)}
+}
+
+// Search picks the best move for the player to move. It iteratively
+// deepens from depth 1 up to MaxDepth, stopping early once TimeBudget
+// elapses and returning the best move found by the last completed
+// depth. Once at most endgameEmpties squares remain, it instead solves
+// the rest of the game exactly against Complete.
+func (s *Searcher) Search(ctx context.Context, board reversi.Board) (move uint64, score int) {
+	hash := zobrist(board)
+	if s.Book != nil {
+		if bookMove, ok := s.Book.Lookup(hash); ok {
+			return bookMove, 0
+		}
+	}
+	if s.tt == nil {
+		s.tt = make(map[uint64]ttEntry)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.TimeBudget)
+	defer cancel()
+
+	empties := 64 - bits.OnesCount64(board.Black|board.White)
+	evaluate, maxDepth := Partial, s.MaxDepth
+	if empties <= endgameEmpties {
+		evaluate, maxDepth = Complete, empties
+	}
+
+	for depth := 1; depth <= maxDepth; depth++ {
+		m, sc, ok := s.searchRoot(ctx, board, depth, evaluate)
+		if !ok {
+			break
+		}
+		move, score = m, sc
+	}
+	return move, score
+}
+
+func (s *Searcher) searchRoot(ctx context.Context, board reversi.Board, depth int, evaluate Evaluator) (move uint64, score int, ok bool) {
+	move, score = s.search(ctx, board, depth, evaluate, math.MinInt, math.MaxInt)
+	select {
+	case <-ctx.Done():
+		return move, score, false
+	default:
+		return move, score, true
+	}
+}
+
+type ttFlag int
+
+const (
+	ttExact ttFlag = iota
+	ttLower
+	ttUpper
+)
+
+type ttEntry struct {
+	depth int
+	flag  ttFlag
+	score int
+	move  uint64
+}
+
+func (s *Searcher) search(ctx context.Context, board reversi.Board, depth int, evaluate Evaluator, alpha, beta int) (uint64, int) {
+	if ctx.Err() != nil {
+		return 0, 0
+	}
+	candidates := board.LegalMoves()
+	if depth == 0 || candidates == 0 {
+		return 0, evaluate(board)
+	}
+
+	hash := zobrist(board)
+	var ttMove uint64
+	if entry, ok := s.tt[hash]; ok {
+		ttMove = entry.move
+		if entry.depth >= depth {
+			switch entry.flag {
+			case ttExact:
+				return entry.move, entry.score
+			case ttLower:
+				if entry.score > alpha {
+					alpha = entry.score
+				}
+			case ttUpper:
+				if entry.score < beta {
+					beta = entry.score
+				}
+			}
+			if alpha >= beta {
+				return entry.move, entry.score
+			}
+		}
+	}
+
+	origAlpha, origBeta := alpha, beta
+	moves := orderMoves(board, candidates, ttMove)
+
+	var bestMove uint64
+	if board.Turn == reversi.Black {
+		bestScore := math.MinInt
+		for _, position := range moves {
+			_, childScore := s.search(ctx, board.Apply(position), depth-1, evaluate, alpha, beta)
+			if childScore > bestScore {
+				bestScore, bestMove = childScore, position
+			}
+			if bestScore > alpha {
+				alpha = bestScore
+			}
+			if alpha >= beta {
+				break
+			}
+		}
+		if ctx.Err() == nil {
+			s.store(hash, depth, bestScore, bestMove, origAlpha, origBeta)
+		}
+		return bestMove, bestScore
+	}
+
+	bestScore := math.MaxInt
+	for _, position := range moves {
+		_, childScore := s.search(ctx, board.Apply(position), depth-1, evaluate, alpha, beta)
+		if childScore < bestScore {
+			bestScore, bestMove = childScore, position
+		}
+		if bestScore < beta {
+			beta = bestScore
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	if ctx.Err() == nil {
+		s.store(hash, depth, bestScore, bestMove, origAlpha, origBeta)
+	}
+	return bestMove, bestScore
+}
+
+func (s *Searcher) store(hash uint64, depth, score int, move uint64, alpha, beta int) {
+	flag := ttExact
+	switch {
+	case score <= alpha:
+		flag = ttUpper
+	case score >= beta:
+		flag = ttLower
+	}
+	if entry, ok := s.tt[hash]; !ok || entry.depth <= depth {
+		s.tt[hash] = ttEntry{depth: depth, flag: flag, score: score, move: move}
+	}
+}
+
+// orderMoves ranks candidates so the search explores the most
+// promising ones first: the transposition table's move, then corners,
+// then the rest sorted by a cheap static evaluation of the resulting
+// position.
+func orderMoves(board reversi.Board, candidates, ttMove uint64) []uint64 {
+	type scoredMove struct {
+		position uint64
+		score    int
+	}
+
+	remaining := candidates &^ ttMove
+	ranked := make([]scoredMove, 0, bits.OnesCount64(remaining))
+	for remaining != 0 {
+		position := uint64(1) << bits.TrailingZeros64(remaining)
+		remaining &= remaining - 1
+		score := Partial(board.Apply(position))
+		if board.Turn == reversi.White {
+			score = -score
+		}
+		ranked = append(ranked, scoredMove{position, score})
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+
+	moves := make([]uint64, 0, bits.OnesCount64(candidates))
+	if ttMove != 0 && ttMove&candidates != 0 {
+		moves = append(moves, ttMove)
+	}
+	var corners, rest []uint64
+	for _, m := range ranked {
+		if m.position&reversi.CornerMask != 0 {
+			corners = append(corners, m.position)
+		} else {
+			rest = append(rest, m.position)
+		}
+	}
+	moves = append(moves, corners...)
+	moves = append(moves, rest...)
+	return moves
+}
@@ -0,0 +1,48 @@
+// (C) 2022 TANIGUCHI Masaya
+// https://git.io/mit-license
+
+package ai
+
+import (
+	"math/bits"
+	"math/rand"
+
+	"github.com/tani/go-reversi/reversi"
+)
+
+// zobristTable holds a pseudo-random key per (color, square) pair,
+// seeded with a fixed constant so hashes are stable across runs and
+// processes, which the transposition table and opening book both rely on.
+var zobristTable [2][64]uint64
+
+// zobristTurn is XORed in when White is to move, so the same stones
+// with the turn flipped (a different node with a disjoint legal-move
+// set) never collides with Black-to-move in the transposition table.
+var zobristTurn uint64
+
+func init() {
+	rng := rand.New(rand.NewSource(2022))
+	for color := 0; color < 2; color++ {
+		for square := 0; square < 64; square++ {
+			zobristTable[color][square] = rng.Uint64()
+		}
+	}
+	zobristTurn = rng.Uint64()
+}
+
+// zobrist computes the Zobrist hash of a board's stone placement and
+// whose turn it is to move; the same stones with the turn flipped hash
+// differently, since they are different nodes in the search tree.
+func zobrist(board reversi.Board) uint64 {
+	hash := uint64(0)
+	for black := board.Black; black != 0; black &= black - 1 {
+		hash ^= zobristTable[0][bits.TrailingZeros64(black)]
+	}
+	for white := board.White; white != 0; white &= white - 1 {
+		hash ^= zobristTable[1][bits.TrailingZeros64(white)]
+	}
+	if board.Turn == reversi.White {
+		hash ^= zobristTurn
+	}
+	return hash
+}
@@ -0,0 +1,193 @@
+// (C) 2022 TANIGUCHI Masaya
+// https://git.io/mit-license
+
+// Package reversi implements Reversi/Othello rules on top of bitboards:
+// legal move generation, move application, and the static evaluators
+// used by search. It has no dependency on any particular UI.
+package reversi
+
+import "math/bits"
+
+// Mask constants used by move generation and static evaluation.
+const (
+	VerticalMask   uint64 = 0x00ffffffffffff00
+	HorizontalMask uint64 = 0x7e7e7e7e7e7e7e7e
+	EdgeMask       uint64 = ^VerticalMask | ^HorizontalMask
+	CornerMask     uint64 = ^VerticalMask & ^(VerticalMask ^ HorizontalMask)
+)
+
+// Player identifies whose turn it is to move.
+type Player int
+
+const (
+	Black Player = iota
+	White
+)
+
+// Opponent returns the other player.
+func (p Player) Opponent() Player {
+	if p == Black {
+		return White
+	}
+	return Black
+}
+
+func (p Player) String() string {
+	if p == Black {
+		return "black"
+	}
+	return "white"
+}
+
+// Board is the immutable game state: the bitboards for both colors and
+// whose turn it is to move.
+type Board struct {
+	Black, White uint64
+	Turn         Player
+}
+
+// NewBoard returns the standard Othello starting position with Black to
+// move first.
+func NewBoard() Board {
+	return Board{
+		Black: (uint64(1) << (8*3 + 4)) | (uint64(1) << (8*4 + 3)),
+		White: (uint64(1) << (8*4 + 4)) | (uint64(1) << (8*3 + 3)),
+		Turn:  Black,
+	}
+}
+
+func (b Board) mine() uint64 {
+	if b.Turn == Black {
+		return b.Black
+	}
+	return b.White
+}
+
+func (b Board) theirs() uint64 {
+	if b.Turn == Black {
+		return b.White
+	}
+	return b.Black
+}
+
+// LegalMoves returns a bitmask with one bit set for every square the
+// player to move may play.
+func (b Board) LegalMoves() uint64 {
+	return GetCandidates(b.mine(), b.theirs())
+}
+
+// Apply returns the board after the player to move plays the single-bit
+// bitmask pos. It does not validate that pos is legal; callers should
+// consult LegalMoves first. If the move leaves the opponent without a
+// legal reply, the turn does not pass to them.
+func (b Board) Apply(pos uint64) Board {
+	reverse := GetReverse(b.mine(), b.theirs(), pos)
+	mine := b.mine() ^ reverse ^ pos
+	theirs := b.theirs() ^ reverse
+
+	next := Board{Turn: b.Turn.Opponent()}
+	if b.Turn == Black {
+		next.Black, next.White = mine, theirs
+	} else {
+		next.Black, next.White = theirs, mine
+	}
+	if next.LegalMoves() == 0 {
+		next.Turn = b.Turn
+	}
+	return next
+}
+
+// FlipCount returns how many opponent stones playing pos would flip for
+// the player to move.
+func (b Board) FlipCount(pos uint64) int {
+	return bits.OnesCount64(GetReverse(b.mine(), b.theirs(), pos))
+}
+
+// GameOver reports whether neither player has a legal move, meaning the
+// game is finished.
+func (b Board) GameOver() bool {
+	return b.LegalMoves() == 0 && GetCandidates(b.theirs(), b.mine()) == 0
+}
+
+// Winner reports the player with more stones on the board. ok is false
+// when the stone counts are tied.
+func (b Board) Winner() (winner Player, ok bool) {
+	blackCount := bits.OnesCount64(b.Black)
+	whiteCount := bits.OnesCount64(b.White)
+	switch {
+	case blackCount > whiteCount:
+		return Black, true
+	case whiteCount > blackCount:
+		return White, true
+	default:
+		return Black, false
+	}
+}
+
+// GetCandidates returns a bitmask of the legal moves for the player
+// represented by black against the opponent white.
+func GetCandidates(black, white uint64) uint64 {
+	mask := [4]uint64{
+		white & HorizontalMask,
+		white & HorizontalMask & VerticalMask,
+		white & VerticalMask,
+		white & HorizontalMask & VerticalMask,
+	}
+	diff := [4]uint64{1, 7, 8, 9}
+	result1 := uint64(0)
+	result2 := uint64(0)
+	for i := 0; i < 4; i++ {
+		pattern1 := mask[i] & (black << diff[i])
+		pattern2 := mask[i] & (black >> diff[i])
+		for j := 0; j < 5; j++ {
+			pattern1 |= mask[i] & (pattern1 << diff[i])
+			pattern2 |= mask[i] & (pattern2 >> diff[i])
+		}
+		result1 |= (pattern1 << diff[i])
+		result2 |= (pattern2 >> diff[i])
+	}
+	return (result1 | result2) & ^(black | white)
+}
+
+// GetReverse returns a bitmask of the opponent stones that would be
+// flipped if black played position against white.
+func GetReverse(black, white, position uint64) uint64 {
+	mask := [4]uint64{
+		white & HorizontalMask,
+		white & HorizontalMask & VerticalMask,
+		white & VerticalMask,
+		white & HorizontalMask & VerticalMask,
+	}
+	diff := [4]uint64{1, 7, 8, 9}
+	result := uint64(0)
+	for i := 0; i < 4; i++ {
+		pattern1 := mask[i] & (black << diff[i])
+		pattern2 := mask[i] & (position >> diff[i])
+		pattern3 := mask[i] & (black >> diff[i])
+		pattern4 := mask[i] & (position << diff[i])
+		for j := 0; j < 5; j++ {
+			pattern1 |= mask[i] & (pattern1 << diff[i])
+			pattern2 |= mask[i] & (pattern2 >> diff[i])
+			pattern3 |= mask[i] & (pattern3 >> diff[i])
+			pattern4 |= mask[i] & (pattern4 << diff[i])
+		}
+		result |= (pattern1 & pattern2) | (pattern3 & pattern4)
+	}
+	return result
+}
+
+// EvaluatePartial is a cheap static evaluator based on mobility and edge
+// control, from black's perspective.
+func EvaluatePartial(black, white uint64) int {
+	positionScore := bits.OnesCount64(black&EdgeMask) - bits.OnesCount64(white&EdgeMask)
+	blackCandidates := GetCandidates(black, white)
+	whiteCandidates := GetCandidates(white, black)
+	mobilityScore := bits.OnesCount64(blackCandidates) - bits.OnesCount64(whiteCandidates)
+	return mobilityScore + 4*positionScore
+}
+
+// EvaluateComplete scores a finished (or near-finished) board by stone
+// count, from black's perspective.
+func EvaluateComplete(black, white uint64) int {
+	return bits.OnesCount64(black) - bits.OnesCount64(white)
+}
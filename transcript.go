@@ -0,0 +1,86 @@
+// (C) 2022 TANIGUCHI Masaya
+// https://git.io/mit-license
+
+//go:build !js
+
+package main
+
+import (
+	"fmt"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tani/go-reversi/reversi"
+)
+
+// writeTranscript saves a finished game's moves to
+// ~/.local/share/go-reversi/games/<timestamp>.rgf in a simple
+// semicolon-separated format: ";B[e4];W[d4];...;RE[B+12]".
+func writeTranscript(moves []moveRecord, board reversi.Board) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(home, ".local", "share", "go-reversi", "games")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	for _, move := range moves {
+		fmt.Fprintf(&sb, ";%s[%s]", colorLetter(move.player), formatCoordinate(move.position))
+	}
+	sb.WriteString(";" + transcriptResult(board))
+
+	path := filepath.Join(dir, fmt.Sprintf("%d.rgf", time.Now().UnixNano()))
+	return os.WriteFile(path, []byte(sb.String()), 0o644)
+}
+
+func colorLetter(player reversi.Player) string {
+	if player == reversi.Black {
+		return "B"
+	}
+	return "W"
+}
+
+// transcriptResult renders the RE[] tag: B+<margin>, W+<margin>, or D.
+func transcriptResult(board reversi.Board) string {
+	blackCount := bits.OnesCount64(board.Black)
+	whiteCount := bits.OnesCount64(board.White)
+	switch {
+	case blackCount > whiteCount:
+		return fmt.Sprintf("RE[B+%d]", blackCount-whiteCount)
+	case whiteCount > blackCount:
+		return fmt.Sprintf("RE[W+%d]", whiteCount-blackCount)
+	default:
+		return "RE[D]"
+	}
+}
+
+// loadReview reads a transcript file and replays it into the sequence
+// of boards -load review mode steps through.
+func loadReview(path string) ([]reversi.Board, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	boards := []reversi.Board{reversi.NewBoard()}
+	for _, token := range strings.Split(strings.TrimSpace(string(data)), ";") {
+		if token == "" || strings.HasPrefix(token, "RE[") {
+			continue
+		}
+		if len(token) < 4 || token[1] != '[' || token[len(token)-1] != ']' {
+			continue
+		}
+		position, ok := parseCoordinate(token[2 : len(token)-1])
+		if !ok {
+			continue
+		}
+		boards = append(boards, boards[len(boards)-1].Apply(position))
+	}
+	return boards, nil
+}
@@ -0,0 +1,20 @@
+// (C) 2022 TANIGUCHI Masaya
+// https://git.io/mit-license
+
+//go:build js
+
+package main
+
+import "github.com/tani/go-reversi/reversi"
+
+// writeTranscript is a no-op in the browser; there is no filesystem to
+// persist match transcripts to.
+func writeTranscript(moves []moveRecord, board reversi.Board) error {
+	return nil
+}
+
+// loadReview is unused in the browser since cfg.load is always empty,
+// but is kept so main.go compiles unchanged.
+func loadReview(path string) ([]reversi.Board, error) {
+	return nil, nil
+}